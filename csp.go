@@ -0,0 +1,70 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NonceFunc returns a per-request CSP nonce for the injected
+// window.httpPreload <script> tag. When set via WithNonceFunc, the
+// middleware also extends any Content-Security-Policy header already set
+// (e.g. by an upstream middleware) with the matching 'nonce-...' source,
+// instead of overwriting it.
+type NonceFunc func(*http.Request) string
+
+// escapeScriptPayload neutralizes "</" sequences in a JSON payload destined
+// for a <script> block, so a handler response body containing "</script>"
+// can't break out of it and inject arbitrary HTML into the page.
+func escapeScriptPayload(b []byte) string {
+	return strings.ReplaceAll(string(b), "</", "<\\/")
+}
+
+// preloadNonce returns the per-request nonce for the injected <script> tag,
+// or "" if nonceFunc isn't set.
+func (ctx *HttpPreloaderContext[T]) preloadNonce(r *http.Request) string {
+	if ctx.nonceFunc == nil {
+		return ""
+	}
+	return ctx.nonceFunc(r)
+}
+
+// buildPreloadScriptTag renders the <script> tag that carries
+// window.httpPreload, tagged with nonce when one was generated.
+func buildPreloadScriptTag(nonce string, payload []byte) string {
+	escaped := escapeScriptPayload(payload)
+	if nonce == "" {
+		return "<script>window.httpPreload=" + escaped + "</script>"
+	}
+	return `<script nonce="` + nonce + `">window.httpPreload=` + escaped + `</script>`
+}
+
+// extendCSPWithNonce adds 'nonce-<nonce>' to the script-src directive of an
+// existing Content-Security-Policy header, or sets a minimal script-src
+// directive if none is present yet. Call this after any handler-set
+// response headers have already been copied onto h, so it extends rather
+// than getting clobbered by them.
+func extendCSPWithNonce(h http.Header, nonce string) {
+	nonceSrc := "'nonce-" + nonce + "'"
+
+	existing := h.Get("Content-Security-Policy")
+	if existing == "" {
+		h.Set("Content-Security-Policy", "script-src 'self' "+nonceSrc)
+		return
+	}
+
+	directives := strings.Split(existing, ";")
+	found := false
+	for i, d := range directives {
+		trimmed := strings.TrimSpace(d)
+		lower := strings.ToLower(trimmed)
+		if lower == "script-src" || strings.HasPrefix(lower, "script-src ") {
+			directives[i] = trimmed + " " + nonceSrc
+			found = true
+			break
+		}
+	}
+	if !found {
+		directives = append(directives, " script-src "+nonceSrc)
+	}
+	h.Set("Content-Security-Policy", strings.Join(directives, ";"))
+}
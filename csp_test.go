@@ -0,0 +1,93 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEscapeScriptPayload(t *testing.T) {
+	got := escapeScriptPayload([]byte(`{"x":"</script><img src=x>"}`))
+	if strings.Contains(got, "</script>") {
+		t.Errorf("got %q, want no unescaped </script>", got)
+	}
+	want := `{"x":"<\/script><img src=x>"}`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildPreloadScriptTagWithoutNonce(t *testing.T) {
+	got := buildPreloadScriptTag("", []byte(`{"a":1}`))
+	want := `<script>window.httpPreload={"a":1}</script>`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildPreloadScriptTagWithNonce(t *testing.T) {
+	got := buildPreloadScriptTag("abc123", []byte(`{"a":1}`))
+	want := `<script nonce="abc123">window.httpPreload={"a":1}</script>`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtendCSPWithNonceNoExistingHeader(t *testing.T) {
+	h := make(http.Header)
+	extendCSPWithNonce(h, "abc123")
+
+	got := h.Get("Content-Security-Policy")
+	want := "script-src 'self' 'nonce-abc123'"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtendCSPWithNonceExistingScriptSrc(t *testing.T) {
+	h := make(http.Header)
+	h.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' https://cdn.example.com")
+	extendCSPWithNonce(h, "abc123")
+
+	got := h.Get("Content-Security-Policy")
+	if !strings.Contains(got, "script-src 'self' https://cdn.example.com 'nonce-abc123'") {
+		t.Errorf("got %q, want the nonce appended to the existing script-src directive", got)
+	}
+	if !strings.Contains(got, "default-src 'self'") {
+		t.Errorf("got %q, want the other directives preserved", got)
+	}
+}
+
+func TestExtendCSPWithNonceCaseInsensitiveDirective(t *testing.T) {
+	h := make(http.Header)
+	h.Set("Content-Security-Policy", "Script-Src 'self'")
+	extendCSPWithNonce(h, "abc123")
+
+	got := h.Get("Content-Security-Policy")
+	if !strings.Contains(got, "'nonce-abc123'") {
+		t.Errorf("got %q, want the nonce appended despite mixed-case directive name", got)
+	}
+	if strings.Count(got, "script-src") > 0 && strings.Count(strings.ToLower(got), "script-src") != 1 {
+		t.Errorf("got %q, want a single script-src directive", got)
+	}
+}
+
+func TestPreloadNonceUsesConfiguredFunc(t *testing.T) {
+	ctx := NewHttpPreloaderContext(struct{}{}, false, WithNonceFunc[struct{}](func(r *http.Request) string {
+		return "fixed-nonce"
+	}))
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if got := ctx.preloadNonce(r); got != "fixed-nonce" {
+		t.Errorf("got %q, want fixed-nonce", got)
+	}
+}
+
+func TestPreloadNonceEmptyWhenUnset(t *testing.T) {
+	ctx := NewHttpPreloaderContext(struct{}{}, false)
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if got := ctx.preloadNonce(r); got != "" {
+		t.Errorf("got %q, want empty string when no NonceFunc is configured", got)
+	}
+}
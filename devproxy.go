@@ -0,0 +1,146 @@
+package HttpPreloader
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxRetryableBodyBytes bounds how much of a request body ServeHTTP will
+// buffer in memory to make it replayable across retries. Requests with a
+// larger or unknown Content-Length are forwarded unbuffered and not retried,
+// since their body can only be read once.
+const maxRetryableBodyBytes = 10 << 20 // 10MiB
+
+// DevServerProxy is a long-lived, retrying reverse proxy used to forward
+// asset requests to the React dev server (Vite/CRA) when no static build is
+// configured. Unlike a bare httputil.ReverseProxy created per-request, it
+// retries connection failures and 502/503/504 responses with exponential
+// backoff, so a dev-server restart doesn't surface as a broken tab.
+type DevServerProxy struct {
+	target       *url.URL
+	transport    http.RoundTripper
+	maxAttempts  int
+	backoff      time.Duration
+	errorHandler func(http.ResponseWriter, *http.Request, error)
+}
+
+// NewDevServerProxy builds a DevServerProxy pointed at target. A nil
+// transport gets a default with a 2s dial timeout and 30s keep-alives;
+// maxAttempts and backoff default to 3 and 100ms when left zero.
+func NewDevServerProxy(target *url.URL, transport *http.Transport, maxAttempts int, backoff time.Duration) *DevServerProxy {
+	if transport == nil {
+		transport = &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   2 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			IdleConnTimeout: 90 * time.Second,
+		}
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	return &DevServerProxy{
+		target:       target,
+		transport:    transport,
+		maxAttempts:  maxAttempts,
+		backoff:      backoff,
+		errorHandler: defaultDevServerErrorHandler,
+	}
+}
+
+// WithErrorHandler overrides the HTML shell rendered once every retry is
+// exhausted.
+func (p *DevServerProxy) WithErrorHandler(h func(http.ResponseWriter, *http.Request, error)) *DevServerProxy {
+	p.errorHandler = h
+	return p
+}
+
+func isRetryableProxyStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+func (p *DevServerProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	backoff := p.backoff
+	var lastErr error
+
+	// A request body can only be read once, so retries need a way to replay
+	// it. Buffer it up front when it's small enough to do so safely;
+	// otherwise forward it unbuffered and don't retry past the first
+	// attempt, since a failed attempt would have already drained it.
+	maxAttempts := p.maxAttempts
+	var bodyBytes []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength < 0 || r.ContentLength > maxRetryableBodyBytes {
+			// Unknown or oversized body: it can only be read once, so don't
+			// buffer it and don't retry past the attempt that consumes it.
+			maxAttempts = 1
+		} else {
+			b, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				p.errorHandler(w, r, err)
+				return
+			}
+			bodyBytes = b
+		}
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		outReq := r.Clone(r.Context())
+		outReq.URL.Scheme = p.target.Scheme
+		outReq.URL.Host = p.target.Host
+		outReq.Host = p.target.Host
+		outReq.RequestURI = ""
+		if bodyBytes != nil {
+			outReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+			outReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+			}
+		}
+
+		resp, err := p.transport.RoundTrip(outReq)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if isRetryableProxyStatus(resp.StatusCode) {
+			lastErr = fmt.Errorf("dev server returned %s", resp.Status)
+			resp.Body.Close()
+			continue
+		}
+
+		for k, vv := range resp.Header {
+			for _, v := range vv {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		resp.Body.Close()
+		return
+	}
+
+	p.errorHandler(w, r, lastErr)
+}
+
+func defaultDevServerErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadGateway)
+	fmt.Fprintf(w, `<!doctype html><html><body><h1>Dev server unreachable</h1><p>%s</p></body></html>`,
+		html.EscapeString(err.Error()))
+}
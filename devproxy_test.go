@@ -0,0 +1,142 @@
+package HttpPreloader
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRoundTripper replays a fixed sequence of responses/errors, one per
+// call, and records the body of each request it sees.
+type fakeRoundTripper struct {
+	responses []*http.Response
+	errs      []error
+	bodies    []string
+	calls     int
+}
+
+func (f *fakeRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	i := f.calls
+	f.calls++
+
+	if r.Body != nil {
+		b, _ := io.ReadAll(r.Body)
+		f.bodies = append(f.bodies, string(b))
+	} else {
+		f.bodies = append(f.bodies, "")
+	}
+
+	if i < len(f.errs) && f.errs[i] != nil {
+		return nil, f.errs[i]
+	}
+	return f.responses[i], nil
+}
+
+func newTestProxy(rt http.RoundTripper) *DevServerProxy {
+	target, _ := url.Parse("http://dev-server.test")
+	return &DevServerProxy{
+		target:       target,
+		transport:    rt,
+		maxAttempts:  3,
+		backoff:      time.Millisecond,
+		errorHandler: defaultDevServerErrorHandler,
+	}
+}
+
+func okResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func badGatewayResponse() *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Status:     "502 Bad Gateway",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+}
+
+func TestDevServerProxyRetriesOnBadGateway(t *testing.T) {
+	rt := &fakeRoundTripper{
+		responses: []*http.Response{badGatewayResponse(), okResponse("hello")},
+	}
+	p := newTestProxy(rt)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if rt.calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", rt.calls)
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Errorf("got status=%d body=%q, want 200 hello", w.Code, w.Body.String())
+	}
+}
+
+func TestDevServerProxyRetainsBodyAcrossRetries(t *testing.T) {
+	rt := &fakeRoundTripper{
+		responses: []*http.Response{badGatewayResponse(), okResponse("ok")},
+	}
+	p := newTestProxy(rt)
+
+	req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader("payload=1"))
+	req.ContentLength = int64(len("payload=1"))
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if rt.calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", rt.calls)
+	}
+	for i, body := range rt.bodies {
+		if body != "payload=1" {
+			t.Errorf("attempt %d: got body=%q, want the original body replayed", i, body)
+		}
+	}
+}
+
+func TestDevServerProxyGivesUpAfterMaxAttempts(t *testing.T) {
+	rt := &fakeRoundTripper{
+		responses: []*http.Response{badGatewayResponse(), badGatewayResponse(), badGatewayResponse()},
+	}
+	p := newTestProxy(rt)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if rt.calls != p.maxAttempts {
+		t.Fatalf("expected %d attempts, got %d", p.maxAttempts, rt.calls)
+	}
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got status=%d, want 502 from the error handler", w.Code)
+	}
+}
+
+func TestDevServerProxyDoesNotRetryUnknownLengthBody(t *testing.T) {
+	rt := &fakeRoundTripper{
+		responses: []*http.Response{badGatewayResponse(), okResponse("unreached")},
+	}
+	p := newTestProxy(rt)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("streamed"))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	if rt.calls != 1 {
+		t.Fatalf("expected exactly 1 attempt for an unbufferable body, got %d", rt.calls)
+	}
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got status=%d, want 502 from the error handler", w.Code)
+	}
+}
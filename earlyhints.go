@@ -0,0 +1,79 @@
+package HttpPreloader
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+var (
+	earlyHintLinkTagRe   = regexp.MustCompile(`(?i)<link\s+[^>]*rel=["'](?:stylesheet|preload)["'][^>]*>`)
+	earlyHintScriptTagRe = regexp.MustCompile(`(?i)<script\s+[^>]*src=["']([^"']+)["'][^>]*>`)
+	earlyHintHrefAttrRe  = regexp.MustCompile(`(?i)href=["']([^"']+)["']`)
+	earlyHintAsAttrRe    = regexp.MustCompile(`(?i)\bas=["']([^"']+)["']`)
+)
+
+// defaultEarlyHintsLinks extracts <link rel="stylesheet">, <script src=...>,
+// and <link rel="preload"> tags out of the React index.html and turns them
+// into Link header values suitable for a 103 Early Hints response.
+func defaultEarlyHintsLinks(reactIndexText string) []string {
+	var links []string
+
+	for _, tag := range earlyHintLinkTagRe.FindAllString(reactIndexText, -1) {
+		href := earlyHintHrefAttrRe.FindStringSubmatch(tag)
+		if href == nil {
+			continue
+		}
+		as := "style"
+		if m := earlyHintAsAttrRe.FindStringSubmatch(tag); m != nil {
+			as = m[1]
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel=preload; as=%s`, href[1], as))
+	}
+
+	for _, m := range earlyHintScriptTagRe.FindAllStringSubmatch(reactIndexText, -1) {
+		links = append(links, fmt.Sprintf(`<%s>; rel=preload; as=script`, m[1]))
+	}
+
+	return links
+}
+
+// earlyHintsLinksFor returns the Link header values for the current
+// index.html head, recomputing them only when the head text has changed
+// since the last call (e.g. after a hot-reloaded index.html) or when the
+// last computation returned no links.
+func (ctx *HttpPreloaderContext[T]) earlyHintsLinksFor(head string) []string {
+	if ctx.earlyHintsDisabled {
+		return nil
+	}
+
+	ctx.earlyHintsMu.Lock()
+	defer ctx.earlyHintsMu.Unlock()
+
+	if head == ctx.lastIndexHead && ctx.earlyHintsLinks != nil {
+		return ctx.earlyHintsLinks
+	}
+
+	fn := ctx.earlyHintsFunc
+	if fn == nil {
+		fn = defaultEarlyHintsLinks
+	}
+	ctx.earlyHintsLinks = fn(head)
+	ctx.lastIndexHead = head
+	return ctx.earlyHintsLinks
+}
+
+// sendEarlyHints emits a 103 Early Hints response carrying a Link header per
+// entry in links, before the real preload work begins. Writing a 1xx status
+// has been supported on both HTTP/1.1 and HTTP/2 since Go 1.19, so this
+// needs no feature probe beforehand.
+func (ctx *HttpPreloaderContext[T]) sendEarlyHints(w http.ResponseWriter, links []string) {
+	if ctx.earlyHintsDisabled || len(links) == 0 {
+		return
+	}
+
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
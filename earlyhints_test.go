@@ -0,0 +1,83 @@
+package HttpPreloader
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultEarlyHintsLinksExtractsStylesheetsScriptsAndPreloads(t *testing.T) {
+	html := `<html><head>
+		<link rel="stylesheet" href="/app.css">
+		<script src="/app.js"></script>
+		<link rel="preload" href="/font.woff2" as="font">
+	</head><body></body></html>`
+
+	links := defaultEarlyHintsLinks(html)
+
+	want := []string{
+		`</app.css>; rel=preload; as=style`,
+		`</font.woff2>; rel=preload; as=font`,
+		`</app.js>; rel=preload; as=script`,
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d: %v", len(links), len(want), links)
+	}
+	for i, w := range want {
+		if links[i] != w {
+			t.Errorf("link %d: got %q, want %q", i, links[i], w)
+		}
+	}
+}
+
+func TestEarlyHintsLinksForCachesUntilHeadChanges(t *testing.T) {
+	ctx := newTestCtx()
+
+	first := ctx.earlyHintsLinksFor(`<link rel="stylesheet" href="/a.css">`)
+	second := ctx.earlyHintsLinksFor(`<link rel="stylesheet" href="/a.css">`)
+	if &first[0] != &second[0] {
+		t.Errorf("expected the cached slice to be reused for an unchanged head")
+	}
+
+	third := ctx.earlyHintsLinksFor(`<link rel="stylesheet" href="/b.css">`)
+	if len(third) != 1 || third[0] != `</b.css>; rel=preload; as=style` {
+		t.Errorf("got %v, want recomputed links for the new head", third)
+	}
+}
+
+func TestEarlyHintsLinksForDisabled(t *testing.T) {
+	ctx := NewHttpPreloaderContext(struct{}{}, false, WithEarlyHintsDisabled[struct{}]())
+
+	if got := ctx.earlyHintsLinksFor(`<link rel="stylesheet" href="/a.css">`); got != nil {
+		t.Errorf("got %v, want nil when early hints are disabled", got)
+	}
+}
+
+func TestSendEarlyHintsWritesLinkHeadersAnd103(t *testing.T) {
+	ctx := newTestCtx()
+	w := httptest.NewRecorder()
+
+	ctx.sendEarlyHints(w, []string{"</a.css>; rel=preload; as=style"})
+
+	if w.Code != 103 {
+		t.Errorf("got status %d, want 103", w.Code)
+	}
+	if got := w.Header().Get("Link"); got != "</a.css>; rel=preload; as=style" {
+		t.Errorf("got Link=%q, want the preload link", got)
+	}
+}
+
+func TestSendEarlyHintsNoopWhenDisabledOrEmpty(t *testing.T) {
+	ctx := NewHttpPreloaderContext(struct{}{}, false, WithEarlyHintsDisabled[struct{}]())
+	w := httptest.NewRecorder()
+	ctx.sendEarlyHints(w, []string{"</a.css>; rel=preload; as=style"})
+	if w.Code != 200 {
+		t.Errorf("got status %d, want no write (default 200) when early hints are disabled", w.Code)
+	}
+
+	ctx2 := newTestCtx()
+	w2 := httptest.NewRecorder()
+	ctx2.sendEarlyHints(w2, nil)
+	if w2.Code != 200 {
+		t.Errorf("got status %d, want no write (default 200) for an empty link list", w2.Code)
+	}
+}
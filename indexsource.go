@@ -0,0 +1,176 @@
+package HttpPreloader
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// splitIndexHTML splits index.html on its closing </body> tag so the
+// preload payload can be mushed in between the two halves.
+func splitIndexHTML(text string) []string {
+	if idx := strings.LastIndex(strings.ToLower(text), "</body>"); idx != -1 {
+		return []string{text[:idx], text[idx:]}
+	}
+	return []string{text, ""}
+}
+
+// indexSource supplies the current index.html, split via splitIndexHTML,
+// re-reading it as needed so a deploy or dev-server rebuild doesn't require
+// restarting the process.
+type indexSource interface {
+	Get() ([]string, error)
+}
+
+// fsSource re-reads index.html from disk, throttled by reloadInterval and
+// skipped entirely if the file's mtime hasn't changed. If a read fails after
+// the first successful load, the last-good parts are returned instead of an
+// error, so a transient disk hiccup doesn't take the process down.
+type fsSource struct {
+	path           string
+	reloadInterval time.Duration
+
+	mu       sync.Mutex
+	parts    []string
+	lastGood []string
+	modTime  time.Time
+	lastStat time.Time
+}
+
+func newFsSource(path string, reloadInterval time.Duration) *fsSource {
+	return &fsSource{path: path, reloadInterval: reloadInterval}
+}
+
+func (s *fsSource) Get() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastStat.IsZero() && time.Since(s.lastStat) < s.reloadInterval {
+		return s.parts, nil
+	}
+	s.lastStat = time.Now()
+
+	stat, err := os.Stat(s.path)
+	if err != nil {
+		if s.lastGood != nil {
+			return s.lastGood, nil
+		}
+		return nil, err
+	}
+	if s.parts != nil && !stat.ModTime().After(s.modTime) {
+		return s.parts, nil
+	}
+
+	body, err := os.ReadFile(s.path)
+	if err != nil {
+		if s.lastGood != nil {
+			return s.lastGood, nil
+		}
+		return nil, err
+	}
+
+	s.modTime = stat.ModTime()
+	s.parts = splitIndexHTML(string(body))
+	s.lastGood = s.parts
+	return s.parts, nil
+}
+
+// proxySource fetches index.html from a running dev server (e.g. Vite/CRA),
+// re-validating with If-Modified-Since/If-None-Match instead of re-fetching
+// and re-splitting on every request. Like fsSource, it keeps a last-good
+// copy so a dev-server restart doesn't take the process down.
+type proxySource struct {
+	url            string
+	client         *http.Client
+	reloadInterval time.Duration
+
+	mu        sync.Mutex
+	parts     []string
+	lastGood  []string
+	etag      string
+	lastMod   string
+	lastFetch time.Time
+}
+
+func newProxySource(url string, reloadInterval time.Duration) *proxySource {
+	return &proxySource{
+		url:            url,
+		client:         &http.Client{Timeout: 5 * time.Second},
+		reloadInterval: reloadInterval,
+	}
+}
+
+func (s *proxySource) Get() ([]string, error) {
+	s.mu.Lock()
+	if !s.lastFetch.IsZero() && time.Since(s.lastFetch) < s.reloadInterval {
+		parts := s.parts
+		s.mu.Unlock()
+		return parts, nil
+	}
+	// Stamp lastFetch before releasing the lock, and regardless of how the
+	// request below turns out, so the throttle above engages even when the
+	// dev server is down instead of re-running the full request every time.
+	s.lastFetch = time.Now()
+	etag, lastMod, lastGood := s.etag, s.lastMod, s.lastGood
+	s.mu.Unlock()
+
+	// The request/response round-trip runs without the lock held, so one
+	// slow or failing dev server doesn't serialize every concurrent page
+	// load behind it.
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		if lastGood != nil {
+			return lastGood, nil
+		}
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastMod != "" {
+		req.Header.Set("If-Modified-Since", lastMod)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if lastGood != nil {
+			return lastGood, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		s.mu.Lock()
+		parts := s.parts
+		s.mu.Unlock()
+		return parts, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if lastGood != nil {
+			return lastGood, nil
+		}
+		return nil, fmt.Errorf("proxy returned %s fetching index.html", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if lastGood != nil {
+			return lastGood, nil
+		}
+		return nil, err
+	}
+
+	parts := splitIndexHTML(string(body))
+	s.mu.Lock()
+	s.etag = resp.Header.Get("ETag")
+	s.lastMod = resp.Header.Get("Last-Modified")
+	s.parts = parts
+	s.lastGood = parts
+	s.mu.Unlock()
+	return parts, nil
+}
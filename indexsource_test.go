@@ -0,0 +1,136 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSplitIndexHTML(t *testing.T) {
+	parts := splitIndexHTML("<html><body>hi</BODY></html>")
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	if parts[0] != "<html><body>hi" || parts[1] != "</BODY></html>" {
+		t.Errorf("got %q / %q", parts[0], parts[1])
+	}
+}
+
+func TestSplitIndexHTMLNoBodyTag(t *testing.T) {
+	parts := splitIndexHTML("<html>no body tag</html>")
+	if parts[0] != "<html>no body tag</html>" || parts[1] != "" {
+		t.Errorf("got %q / %q, want the whole text in parts[0]", parts[0], parts[1])
+	}
+}
+
+func TestFsSourceReadsAndCaches(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(path, []byte("<html><body>v1</body></html>"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	s := newFsSource(path, 0)
+	parts, err := s.Get()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if parts[0] != "<html><body>v1" {
+		t.Errorf("got %q", parts[0])
+	}
+}
+
+func TestFsSourceFallsBackToLastGoodOnMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.html")
+	os.WriteFile(path, []byte("<html><body>v1</body></html>"), 0o644)
+
+	s := newFsSource(path, 0)
+	if _, err := s.Get(); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	os.Remove(path)
+	parts, err := s.Get()
+	if err != nil {
+		t.Fatalf("expected last-good fallback, got error: %v", err)
+	}
+	if parts[0] != "<html><body>v1" {
+		t.Errorf("got %q, want the last-good parts", parts[0])
+	}
+}
+
+func TestFsSourceErrorsWithoutLastGood(t *testing.T) {
+	s := newFsSource(filepath.Join(t.TempDir(), "missing.html"), 0)
+	if _, err := s.Get(); err == nil {
+		t.Errorf("expected an error when no file exists and no last-good copy is cached")
+	}
+}
+
+func TestProxySourceFetchesAndCachesLastGood(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>from-dev-server</body></html>"))
+	}))
+	defer srv.Close()
+
+	s := newProxySource(srv.URL, 0)
+	parts, err := s.Get()
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if parts[0] != "<html><body>from-dev-server" {
+		t.Errorf("got %q", parts[0])
+	}
+}
+
+func TestProxySourceFallsBackToLastGoodOnError(t *testing.T) {
+	callCount := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		if callCount == 1 {
+			w.Write([]byte("<html><body>good</body></html>"))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s := newProxySource(srv.URL, 0)
+	if _, err := s.Get(); err != nil {
+		t.Fatalf("first Get: %v", err)
+	}
+
+	parts, err := s.Get()
+	if err != nil {
+		t.Fatalf("expected last-good fallback on a 502, got error: %v", err)
+	}
+	if parts[0] != "<html><body>good" {
+		t.Errorf("got %q, want the last-good parts", parts[0])
+	}
+}
+
+func TestProxySourceStampsLastFetchEvenOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s := newProxySource(srv.URL, time.Hour)
+	if _, err := s.Get(); err == nil {
+		t.Fatalf("expected an error with no last-good copy and a failing dev server")
+	}
+	if s.lastFetch.IsZero() {
+		t.Fatalf("expected lastFetch to be stamped even when the request fails, so the throttle engages")
+	}
+
+	// A second call within reloadInterval must be throttled rather than
+	// re-issuing the request, even though the prior attempt failed.
+	before := s.lastFetch
+	s.Get()
+	if s.lastFetch != before {
+		t.Errorf("expected lastFetch to stay unchanged while throttled")
+	}
+}
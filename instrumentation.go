@@ -0,0 +1,49 @@
+package HttpPreloader
+
+import "time"
+
+// Instrumentation lets callers bridge preload fan-out metrics to Prometheus,
+// OpenTelemetry, or a log sink without this package importing any of them
+// directly. Set HttpPreloaderContext.Instrumentation to start recording.
+type Instrumentation interface {
+	// ObservePreload records one matched handler's execution, during either
+	// the preload pass or a direct /api call. pattern is "METHOD
+	// matched-pattern", status is the handler's response status code, dur is
+	// how long it took, and bytes is the size of the body captured from it,
+	// which is at most the configured max body size (see WithMaxBodyBytes).
+	ObservePreload(pattern string, status int, dur time.Duration, bytes int)
+
+	// PreloadGoroutineStarted/Finished bracket a single preload goroutine,
+	// for a gauge of in-flight preload goroutines per request.
+	PreloadGoroutineStarted()
+	PreloadGoroutineFinished()
+
+	// ObservePayloadBytes records the size, in bytes, of the final
+	// window.httpPreload JSON blob injected into index.html.
+	ObservePayloadBytes(bytes int)
+}
+
+func (ctx *HttpPreloaderContext[T]) observePreload(method, pattern string, status int, dur time.Duration, bytes int) {
+	if ctx.Instrumentation == nil {
+		return
+	}
+	ctx.Instrumentation.ObservePreload(method+" "+pattern, status, dur, bytes)
+}
+
+func (ctx *HttpPreloaderContext[T]) preloadGoroutineStarted() {
+	if ctx.Instrumentation != nil {
+		ctx.Instrumentation.PreloadGoroutineStarted()
+	}
+}
+
+func (ctx *HttpPreloaderContext[T]) preloadGoroutineFinished() {
+	if ctx.Instrumentation != nil {
+		ctx.Instrumentation.PreloadGoroutineFinished()
+	}
+}
+
+func (ctx *HttpPreloaderContext[T]) observePayloadBytes(n int) {
+	if ctx.Instrumentation != nil {
+		ctx.Instrumentation.ObservePayloadBytes(n)
+	}
+}
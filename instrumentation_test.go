@@ -0,0 +1,78 @@
+package HttpPreloader
+
+import (
+	"testing"
+	"time"
+)
+
+type recordingInstrumentation struct {
+	observed         []string
+	started          int
+	finished         int
+	payloadBytes     []int
+	lastStatus       int
+	lastDur          time.Duration
+	lastBytesWritten int
+}
+
+func (r *recordingInstrumentation) ObservePreload(pattern string, status int, dur time.Duration, bytes int) {
+	r.observed = append(r.observed, pattern)
+	r.lastStatus = status
+	r.lastDur = dur
+	r.lastBytesWritten = bytes
+}
+func (r *recordingInstrumentation) PreloadGoroutineStarted()  { r.started++ }
+func (r *recordingInstrumentation) PreloadGoroutineFinished() { r.finished++ }
+func (r *recordingInstrumentation) ObservePayloadBytes(n int) {
+	r.payloadBytes = append(r.payloadBytes, n)
+}
+
+func TestObservePreloadForwardsToInstrumentation(t *testing.T) {
+	rec := &recordingInstrumentation{}
+	ctx := newTestCtx()
+	ctx.Instrumentation = rec
+
+	ctx.observePreload("GET", "/users/:id", 200, 5*time.Millisecond, 123)
+
+	if len(rec.observed) != 1 || rec.observed[0] != "GET /users/:id" {
+		t.Errorf("got observed=%v, want [\"GET /users/:id\"]", rec.observed)
+	}
+	if rec.lastStatus != 200 || rec.lastBytesWritten != 123 {
+		t.Errorf("got status=%d bytes=%d, want 200/123", rec.lastStatus, rec.lastBytesWritten)
+	}
+}
+
+func TestInstrumentationHooksAreNilSafe(t *testing.T) {
+	ctx := newTestCtx()
+
+	ctx.observePreload("GET", "/x", 200, time.Millisecond, 1)
+	ctx.preloadGoroutineStarted()
+	ctx.preloadGoroutineFinished()
+	ctx.observePayloadBytes(10)
+}
+
+func TestPreloadGoroutineStartedFinishedForwarded(t *testing.T) {
+	rec := &recordingInstrumentation{}
+	ctx := newTestCtx()
+	ctx.Instrumentation = rec
+
+	ctx.preloadGoroutineStarted()
+	ctx.preloadGoroutineStarted()
+	ctx.preloadGoroutineFinished()
+
+	if rec.started != 2 || rec.finished != 1 {
+		t.Errorf("got started=%d finished=%d, want 2/1", rec.started, rec.finished)
+	}
+}
+
+func TestObservePayloadBytesForwarded(t *testing.T) {
+	rec := &recordingInstrumentation{}
+	ctx := newTestCtx()
+	ctx.Instrumentation = rec
+
+	ctx.observePayloadBytes(42)
+
+	if len(rec.payloadBytes) != 1 || rec.payloadBytes[0] != 42 {
+		t.Errorf("got payloadBytes=%v, want [42]", rec.payloadBytes)
+	}
+}
@@ -0,0 +1,126 @@
+package HttpPreloader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"unicode/utf8"
+)
+
+// InterceptWriter is the http.ResponseWriter handed to a handler during the
+// preload pass or a direct /api call: it captures the response instead of
+// writing it to the wire, so it can be folded into window.httpPreload.
+type InterceptWriter struct {
+	Headers      http.Header
+	StatusCode   int
+	Truncated    bool
+	maxBodyBytes int
+	buf          *bytes.Buffer
+}
+
+// NewInterceptWriter returns an InterceptWriter that discards writes past
+// maxBodyBytes (marking itself Truncated) instead of growing without bound.
+// A maxBodyBytes of 0 means unlimited.
+func NewInterceptWriter(maxBodyBytes int) *InterceptWriter {
+	return &InterceptWriter{
+		Headers:      make(http.Header),
+		StatusCode:   http.StatusOK,
+		maxBodyBytes: maxBodyBytes,
+		buf:          new(bytes.Buffer),
+	}
+}
+
+func (iw *InterceptWriter) Header() http.Header {
+	return iw.Headers
+}
+
+func (iw *InterceptWriter) WriteHeader(code int) {
+	iw.StatusCode = code
+}
+
+func (iw *InterceptWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	if iw.maxBodyBytes > 0 {
+		if remaining := iw.maxBodyBytes - iw.buf.Len(); remaining < len(b) {
+			if remaining < 0 {
+				remaining = 0
+			}
+			b = b[:remaining]
+			iw.Truncated = true
+		}
+	}
+	iw.buf.Write(b)
+	return n, nil // report the full length so callers don't see a short write
+}
+
+// Flush is a no-op: InterceptWriter never streams to a client, but handlers
+// that reach for http.Flusher (e.g. SSE endpoints) shouldn't panic during
+// the preload pass.
+func (iw *InterceptWriter) Flush() {}
+
+// Hijack always fails: InterceptWriter has no underlying connection to hand
+// over. It exists so handlers that type-assert http.Hijacker don't panic.
+func (iw *InterceptWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+// ReadFrom lets handlers that type-assert io.ReaderFrom (e.g. io.Copy
+// fast-pathing) stream straight into the capture buffer, respecting the
+// same maxBodyBytes cap as Write.
+func (iw *InterceptWriter) ReadFrom(r io.Reader) (int64, error) {
+	if iw.maxBodyBytes == 0 {
+		return iw.buf.ReadFrom(r)
+	}
+
+	remaining := iw.maxBodyBytes - iw.buf.Len()
+	if remaining <= 0 {
+		// Still drain r so the handler doesn't see a write error, we just
+		// don't keep any more of it.
+		n, err := io.Copy(io.Discard, r)
+		if n > 0 {
+			iw.Truncated = true
+		}
+		return n, err
+	}
+
+	n, err := io.CopyN(iw.buf, r, int64(remaining))
+	if err == io.EOF {
+		err = nil
+	}
+	if discarded, _ := io.Copy(io.Discard, r); discarded > 0 {
+		iw.Truncated = true
+	}
+	return n, err
+}
+
+// MarshalJSON marshals the captured response lazily: the body is only
+// stringified here, not on every Write. Non-UTF-8 bodies (binary API
+// responses) are base64-encoded with bodyEncoding set accordingly, so they
+// survive the JSON round-trip into the browser.
+func (iw *InterceptWriter) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Headers      http.Header `json:"headers"`
+		StatusCode   int         `json:"statusCode"`
+		Body         string      `json:"body"`
+		BodyEncoding string      `json:"bodyEncoding,omitempty"`
+		Truncated    bool        `json:"truncated,omitempty"`
+	}{
+		Headers:    iw.Headers,
+		StatusCode: iw.StatusCode,
+		Truncated:  iw.Truncated,
+	}
+
+	body := iw.buf.Bytes()
+	if utf8.Valid(body) {
+		out.Body = string(body)
+	} else {
+		out.Body = base64.StdEncoding.EncodeToString(body)
+		out.BodyEncoding = "base64"
+	}
+
+	return json.Marshal(out)
+}
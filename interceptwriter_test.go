@@ -0,0 +1,115 @@
+package HttpPreloader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestInterceptWriterCapturesStatusHeadersAndBody(t *testing.T) {
+	iw := NewInterceptWriter(0)
+	iw.Header().Set("X-Test", "1")
+	iw.WriteHeader(201)
+	iw.Write([]byte("hello"))
+
+	if iw.StatusCode != 201 {
+		t.Errorf("got StatusCode=%d, want 201", iw.StatusCode)
+	}
+	if iw.Headers.Get("X-Test") != "1" {
+		t.Errorf("got X-Test=%q, want 1", iw.Headers.Get("X-Test"))
+	}
+	if iw.buf.String() != "hello" {
+		t.Errorf("got body=%q, want hello", iw.buf.String())
+	}
+}
+
+func TestInterceptWriterWriteTruncatesPastMaxBodyBytes(t *testing.T) {
+	iw := NewInterceptWriter(5)
+	n, err := iw.Write([]byte("hello world"))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if n != len("hello world") {
+		t.Errorf("got n=%d, want the full length reported even though truncated", n)
+	}
+	if iw.buf.String() != "hello" {
+		t.Errorf("got buf=%q, want only the first 5 bytes kept", iw.buf.String())
+	}
+	if !iw.Truncated {
+		t.Errorf("expected Truncated to be set")
+	}
+}
+
+func TestInterceptWriterWriteUnderCapIsNotTruncated(t *testing.T) {
+	iw := NewInterceptWriter(5)
+	iw.Write([]byte("hi"))
+	if iw.Truncated {
+		t.Errorf("expected Truncated to stay false for a write under the cap")
+	}
+}
+
+func TestInterceptWriterReadFromRespectsCap(t *testing.T) {
+	iw := NewInterceptWriter(5)
+	n, err := iw.ReadFrom(strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("got n=%d, want 5 bytes copied into the capped buffer", n)
+	}
+	if iw.buf.String() != "hello" {
+		t.Errorf("got buf=%q, want hello", iw.buf.String())
+	}
+	if !iw.Truncated {
+		t.Errorf("expected Truncated to be set")
+	}
+}
+
+func TestInterceptWriterMarshalJSONUTF8Body(t *testing.T) {
+	iw := NewInterceptWriter(0)
+	iw.WriteHeader(200)
+	iw.Write([]byte(`{"ok":true}`))
+
+	b, err := iw.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var out struct {
+		StatusCode   int    `json:"statusCode"`
+		Body         string `json:"body"`
+		BodyEncoding string `json:"bodyEncoding,omitempty"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.StatusCode != 200 || out.Body != `{"ok":true}` || out.BodyEncoding != "" {
+		t.Errorf("got %+v", out)
+	}
+}
+
+func TestInterceptWriterMarshalJSONBinaryBodyIsBase64(t *testing.T) {
+	iw := NewInterceptWriter(0)
+	iw.Write([]byte{0xff, 0xfe, 0x00, 0x01})
+
+	b, err := iw.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var out struct {
+		Body         string `json:"body"`
+		BodyEncoding string `json:"bodyEncoding"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.BodyEncoding != "base64" {
+		t.Errorf("got bodyEncoding=%q, want base64 for a non-UTF-8 body", out.BodyEncoding)
+	}
+}
+
+func TestInterceptWriterHijackAlwaysFails(t *testing.T) {
+	iw := NewInterceptWriter(0)
+	if _, _, err := iw.Hijack(); err == nil {
+		t.Errorf("expected Hijack to always return an error")
+	}
+}
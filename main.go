@@ -1,17 +1,16 @@
 package HttpPreloader
 
 import (
-	"bytes"
 	"encoding/json"
-	"io"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 func isDev() bool {
@@ -20,43 +19,63 @@ func isDev() bool {
 
 type PreloadRouteMap struct {
 	Path    string
-	Handler func(w http.ResponseWriter, r *http.Request)
+	Handler http.Handler
+	regex   *regexp.Regexp
 }
 
-type InterceptWriter struct {
-	Headers    http.Header `json:"headers"`
-	StatusCode int         `json:"statusCode"`
-	Body       string      `json:"body"`
-	buf        *bytes.Buffer
-}
-
-func NewInterceptWriter() *InterceptWriter {
-	return &InterceptWriter{
-		Headers:    make(http.Header),
-		StatusCode: http.StatusOK,
-		buf:        new(bytes.Buffer),
-	}
-}
-
-func (iw *InterceptWriter) Header() http.Header {
-	return iw.Headers
+type HttpPreloaderContext[T any] struct {
+	routes               map[string][]*PreloadRouteMap
+	UserData             T
+	staggaredTestingMode bool
+	middlewares          []Middleware
+	earlyHintsDisabled   bool
+	earlyHintsFunc       func(reactIndexText string) []string
+	Instrumentation      Instrumentation
+	reloadInterval       time.Duration
+
+	indexSource indexSource
+
+	earlyHintsMu    sync.Mutex
+	earlyHintsLinks []string
+	lastIndexHead   string
+
+	devServerURL          string
+	devServerTransport    *http.Transport
+	devServerMaxAttempts  int
+	devServerBackoff      time.Duration
+	devServerErrorHandler func(http.ResponseWriter, *http.Request, error)
+	devServerProxy        *DevServerProxy
+
+	// maxBodyBytes caps how much of a handler's response body is captured
+	// into the preload payload; writes past the cap are discarded silently
+	// and the InterceptWriter is marked Truncated. Zero means unlimited.
+	maxBodyBytes int
+
+	// nonceFunc, when set, generates the CSP nonce used on the injected
+	// window.httpPreload <script> tag and extends the response's
+	// Content-Security-Policy header to allow it.
+	nonceFunc NonceFunc
 }
 
-func (iw *InterceptWriter) WriteHeader(code int) {
-	iw.StatusCode = code
+func (ctx *HttpPreloaderContext[T]) newInterceptWriter() *InterceptWriter {
+	return NewInterceptWriter(ctx.maxBodyBytes)
 }
 
-func (iw *InterceptWriter) Write(b []byte) (int, error) {
-	iw.buf.Write(b)           // capture body
-	iw.Body = iw.buf.String() // keep as string
-	return len(b), nil
+// Use registers middlewares that wrap every route on this context, for both
+// direct /api calls and the preload goroutines spawned inside HttpPreloader.
+func (ctx *HttpPreloaderContext[T]) Use(mw ...Middleware) {
+	ctx.middlewares = append(ctx.middlewares, mw...)
 }
 
-type HttpPreloaderContext[T any] struct {
-	routes               map[string]map[string]PreloadRouteMap
-	UserData             T
-	staggaredTestingMode bool
-	reactIndexTextParts  []string // the sandwich where we mush the preload data into
+// Group returns a RouteBuilder that registers routes under prefix. The
+// context's own middlewares (added via Use) already wrap every route at
+// dispatch time, so they are not copied in here too - doing so would run
+// them twice per request for anything registered through this builder.
+func (ctx *HttpPreloaderContext[T]) Group(prefix string) *RouteBuilder[T] {
+	return &RouteBuilder[T]{
+		ctx:    ctx,
+		prefix: prefix,
+	}
 }
 
 func (ctx *HttpPreloaderContext[T]) Get(pattern string, handler http.HandlerFunc) {
@@ -78,19 +97,46 @@ func (ctx *HttpPreloaderContext[T]) Handle(method, pattern string, handler http.
 	ctx.addRoute(method, pattern, handler)
 }
 
-func (ctx *HttpPreloaderContext[T]) addRoute(method, pattern string, handler http.HandlerFunc) {
-	if ctx.routes[method] == nil {
-		ctx.routes[method] = make(map[string]PreloadRouteMap)
+func (ctx *HttpPreloaderContext[T]) addRoute(method, pattern string, handler http.HandlerFunc, mws ...Middleware) {
+	ctx.routes[method] = append(ctx.routes[method], &PreloadRouteMap{
+		Path:    pattern,
+		Handler: Chain(handler, mws...),
+		regex:   compilePattern(pattern),
+	})
+}
+
+// HandleRegex registers a route matched against a raw regular expression
+// instead of the ":param"/"*wildcard" shorthand, for patterns the shorthand
+// can't express.
+func (ctx *HttpPreloaderContext[T]) HandleRegex(method string, re *regexp.Regexp, handler http.HandlerFunc, mws ...Middleware) {
+	ctx.routes[method] = append(ctx.routes[method], &PreloadRouteMap{
+		Path:    re.String(),
+		Handler: Chain(handler, mws...),
+		regex:   re,
+	})
+}
+
+// dispatch runs a matched route's handler through the context's global
+// middleware stack, so auth/logging/etc apply the same way to the preload
+// goroutines inside HttpPreloader as they do to direct /api calls.
+func (ctx *HttpPreloaderContext[T]) dispatch(route *PreloadRouteMap, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	Chain(route.Handler, ctx.middlewares...).ServeHTTP(w, r)
+	if iw, ok := w.(*InterceptWriter); ok {
+		ctx.observePreload(r.Method, route.Path, iw.StatusCode, time.Since(start), iw.buf.Len())
 	}
-	ctx.routes[method][pattern] = PreloadRouteMap{Path: pattern, Handler: handler}
 }
 
-func NewHttpPreloaderContext[T any](userData T, staggaredTestingMode bool) *HttpPreloaderContext[T] {
-	return &HttpPreloaderContext[T]{
-		routes:               make(map[string]map[string]PreloadRouteMap),
+func NewHttpPreloaderContext[T any](userData T, staggaredTestingMode bool, opts ...Option[T]) *HttpPreloaderContext[T] {
+	ctx := &HttpPreloaderContext[T]{
+		routes:               make(map[string][]*PreloadRouteMap),
 		UserData:             userData,
 		staggaredTestingMode: staggaredTestingMode,
 	}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	return ctx
 }
 
 func requestIsDefaultIndex(path string) bool {
@@ -112,8 +158,6 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 	apiPrefix string,
 	reactAppBuildRoot string,
 ) http.Handler {
-	// Build react parts here
-
 	useProxy := false
 	if reactAppBuildRoot == "" {
 		useProxy = true
@@ -123,45 +167,35 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 		}
 	}
 
-	var reactIndexText, errMsg string
+	if ctx.devServerURL == "" {
+		ctx.devServerURL = "http://localhost:3000"
+	}
+
 	if useProxy {
-		// fetch index.html from proxy
-		target := "http://localhost:3000/index.html"
-		resp, err := http.Get(target)
-		if err != nil {
-			errMsg = "failed to fetch index.html from proxy"
-		}
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		reactIndexText = string(body)
-	} else {
-		// fetch index.html from disk
-		reqPath := filepath.Join(reactAppBuildRoot, "index.html")
-		body, err := os.ReadFile(reqPath)
+		ctx.indexSource = newProxySource(ctx.devServerURL+"/index.html", ctx.reloadInterval)
+
+		target, err := url.Parse(ctx.devServerURL)
 		if err != nil {
-			errMsg = "failed to read index.html from disk"
+			log.Fatal("Critical error: invalid dev server URL: ", err)
+			return nil
 		}
-		reactIndexText = string(body)
-	}
-
-	if idx := strings.LastIndex(strings.ToLower(reactIndexText), "</body>"); idx != -1 {
-		ctx.reactIndexTextParts = []string{
-			reactIndexText[:idx], // everything before </body>
-			reactIndexText[idx:], // </body> and after
+		ctx.devServerProxy = NewDevServerProxy(target, ctx.devServerTransport, ctx.devServerMaxAttempts, ctx.devServerBackoff)
+		if ctx.devServerErrorHandler != nil {
+			ctx.devServerProxy.WithErrorHandler(ctx.devServerErrorHandler)
 		}
 	} else {
-		ctx.reactIndexTextParts = []string{
-			reactIndexText,
-			"",
-		}
+		ctx.indexSource = newFsSource(filepath.Join(reactAppBuildRoot, "index.html"), ctx.reloadInterval)
 	}
 
-	if errMsg != "" {
-		log.Fatal("Critical error:", errMsg)
+	// Warm the cache once so we fail fast if index.html can't be loaded at
+	// all. Once we have a last-good copy, later transient read/fetch errors
+	// fall back to it instead of taking the process down.
+	if _, err := ctx.indexSource.Get(); err != nil {
+		log.Fatal("Critical error: failed to load index.html: ", err)
 		return nil
 	}
 
-	println("React Index Cached, will need to restart if index.html is updated.")
+	println("React Index will be re-checked for changes on each request, at most once per reload interval.")
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		uri := r.URL.Path
@@ -170,10 +204,7 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 
 			if !requestIsDefaultIndex(r.URL.Path) {
 				if useProxy {
-					target := "http://localhost:3000"
-					url, _ := url.Parse(target)
-					proxy := httputil.NewSingleHostReverseProxy(url)
-					proxy.ServeHTTP(w, r)
+					ctx.devServerProxy.ServeHTTP(w, r)
 				} else {
 					reqPath := filepath.Join(reactAppBuildRoot, r.URL.Path)
 					if stat, err := os.Stat(reqPath); err == nil && !stat.IsDir() {
@@ -185,6 +216,14 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 				return
 			}
 
+			indexParts, err := ctx.indexSource.Get()
+			if err != nil {
+				http.Error(w, "index.html unavailable", http.StatusInternalServerError)
+				return
+			}
+
+			ctx.sendEarlyHints(w, ctx.earlyHintsLinksFor(indexParts[0]))
+
 			// Build the preloader handler requests:
 			if !ctx.staggaredTestingMode {
 				parts := strings.SplitN(r.RequestURI, "?", 2)
@@ -205,17 +244,19 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 					wg.Add(1)
 					go func() {
 						defer wg.Done()
-						if route, ok := ctx.routes[r.Method][currentPathReq]; ok {
-							preloadWriter := NewInterceptWriter()
+						ctx.preloadGoroutineStarted()
+						defer ctx.preloadGoroutineFinished()
+						if route, params, ok := ctx.matchRoute(r.Method, currentPathReq); ok {
+							preloadWriter := ctx.newInterceptWriter()
 							if i == len(pathSegments)-1 {
-								route.Handler(preloadWriter, r)
+								ctx.dispatch(route, preloadWriter, withPathParams(r, params))
 							} else {
 								// Hacking to remove query parameters when not full path request handled:
 								rCopy := r.Clone(r.Context())
 								urlCopy := *rCopy.URL
 								urlCopy.RawQuery = ""
 								rCopy.URL = &urlCopy
-								route.Handler(preloadWriter, rCopy)
+								ctx.dispatch(route, preloadWriter, withPathParams(rCopy, params))
 							}
 
 							if i == len(pathSegments)-1 {
@@ -232,18 +273,12 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 				wg.Wait()
 
 				// Done handoing all data requests, now bundle it in .html request:
-
-				//ctx.reactIndexTextParts
 				responseJSON, _ := json.Marshal(preloadRequests)
-				varString := "<script>window.httpPreload=" + string(responseJSON) + "</script>"
+				ctx.observePayloadBytes(len(responseJSON))
+				nonce := ctx.preloadNonce(r)
+				varString := buildPreloadScriptTag(nonce, responseJSON)
 
-				// if idx := strings.LastIndex(strings.ToLower(reactIndexText), "</body>"); idx != -1 {
-				// 	reactIndexText = reactIndexText[:idx] + varString + reactIndexText[idx:]
-				// } else {
-				// 	reactIndexText += varString
-				// }
-
-				requestedReactIndexText := ctx.reactIndexTextParts[0] + varString + ctx.reactIndexTextParts[1]
+				requestedReactIndexText := indexParts[0] + varString + indexParts[1]
 
 				if iw, ok := preloadRequests[r.RequestURI]; ok && iw != nil {
 					if iw.StatusCode != 404 {
@@ -254,11 +289,17 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 						}
 					}
 				}
+				// Extend the CSP after any handler-set headers above have
+				// been copied in, so we add to the real policy rather than
+				// being clobbered by it.
+				if nonce != "" {
+					extendCSPWithNonce(w.Header(), nonce)
+				}
 				w.Write([]byte(requestedReactIndexText))
 				return
 			}
 
-			w.Write([]byte(ctx.reactIndexTextParts[0] + ctx.reactIndexTextParts[1]))
+			w.Write([]byte(indexParts[0] + indexParts[1]))
 		} else {
 			// this is a API request, need to handler by prepending`apiPrefix`
 			println(r.URL.Path)
@@ -268,9 +309,19 @@ func (ctx *HttpPreloaderContext[T]) HttpPreloader(
 			}
 
 			// Check if route exists
-			if route, ok := ctx.routes[r.Method][directApiPath]; ok {
-				preloadWriter := NewInterceptWriter()
-				route.Handler(preloadWriter, r)
+			if route, params, ok := ctx.matchRoute(r.Method, directApiPath); ok {
+				preloadWriter := ctx.newInterceptWriter()
+				ctx.dispatch(route, preloadWriter, withPathParams(r, params))
+				// Propagate the intercepted status and headers onto the real
+				// response so a middleware that rejects the request (e.g.
+				// auth returning 401) is actually reflected to the client,
+				// not just to the preload-map entry.
+				for k, vv := range preloadWriter.Headers {
+					for _, v := range vv {
+						w.Header().Add(k, v)
+					}
+				}
+				w.WriteHeader(preloadWriter.StatusCode)
 				w.Write(preloadWriter.buf.Bytes())
 			}
 		}
@@ -0,0 +1,61 @@
+package HttpPreloader
+
+import "net/http"
+
+// Middleware wraps an http.Handler to add behavior such as auth, logging,
+// rate limiting, or request-scoped context injection.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies mws around h, with mws[0] running outermost (first).
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// RouteBuilder registers routes under a common prefix with a middleware
+// stack inherited from the HttpPreloaderContext it was created from. Use
+// HttpPreloaderContext.Group to obtain one.
+type RouteBuilder[T any] struct {
+	ctx         *HttpPreloaderContext[T]
+	prefix      string
+	middlewares []Middleware
+}
+
+// Use appends middlewares to the group's stack. They run after any
+// middlewares already registered on the group, and after the context's
+// global middlewares (added via HttpPreloaderContext.Use).
+func (rb *RouteBuilder[T]) Use(mw ...Middleware) *RouteBuilder[T] {
+	rb.middlewares = append(rb.middlewares, mw...)
+	return rb
+}
+
+// Group returns a new RouteBuilder nested under this one, with its prefix
+// appended and its middleware stack inherited.
+func (rb *RouteBuilder[T]) Group(prefix string) *RouteBuilder[T] {
+	return &RouteBuilder[T]{
+		ctx:         rb.ctx,
+		prefix:      rb.prefix + prefix,
+		middlewares: append([]Middleware{}, rb.middlewares...),
+	}
+}
+
+func (rb *RouteBuilder[T]) Get(pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(http.MethodGet, rb.prefix+pattern, handler, rb.middlewares...)
+}
+func (rb *RouteBuilder[T]) Post(pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(http.MethodPost, rb.prefix+pattern, handler, rb.middlewares...)
+}
+func (rb *RouteBuilder[T]) Put(pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(http.MethodPut, rb.prefix+pattern, handler, rb.middlewares...)
+}
+func (rb *RouteBuilder[T]) Patch(pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(http.MethodPatch, rb.prefix+pattern, handler, rb.middlewares...)
+}
+func (rb *RouteBuilder[T]) Delete(pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(http.MethodDelete, rb.prefix+pattern, handler, rb.middlewares...)
+}
+func (rb *RouteBuilder[T]) Handle(method, pattern string, handler http.HandlerFunc) {
+	rb.ctx.addRoute(method, rb.prefix+pattern, handler, rb.middlewares...)
+}
@@ -0,0 +1,92 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rejectingMiddleware short-circuits every request with statusCode, never
+// calling the wrapped handler.
+func rejectingMiddleware(statusCode int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Rejected-By", "auth")
+			w.WriteHeader(statusCode)
+		})
+	}
+}
+
+func TestUseAppliesToGroupRoutesExactlyOnce(t *testing.T) {
+	ctx := newTestCtx()
+
+	calls := 0
+	ctx.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx.Group("/api").Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	route, params, ok := ctx.matchRoute(http.MethodGet, "/api/ping")
+	if !ok {
+		t.Fatalf("expected route match")
+	}
+
+	ctx.dispatch(route, httptest.NewRecorder(), withPathParams(httptest.NewRequest(http.MethodGet, "/api/ping", nil), params))
+
+	if calls != 1 {
+		t.Errorf("got %d calls to the context middleware, want exactly 1", calls)
+	}
+}
+
+func TestHttpPreloaderPropagatesRejectingMiddlewareStatus(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(indexPath, []byte("<html><body>app</body></html>"), 0o644); err != nil {
+		t.Fatalf("failed to write index.html: %v", err)
+	}
+
+	ctx := NewHttpPreloaderContext(struct{}{}, false, WithEarlyHintsDisabled[struct{}]())
+	ctx.Use(rejectingMiddleware(http.StatusUnauthorized))
+	ctx.Group("").Get("/secret", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("top secret"))
+	})
+
+	handler := ctx.HttpPreloader(nil, "/api", dir)
+	if handler == nil {
+		t.Fatalf("HttpPreloader returned a nil handler")
+	}
+
+	// Direct /api call: the real response must carry the middleware's
+	// rejection, not a 200.
+	directReq := httptest.NewRequest(http.MethodGet, "/api/secret", nil)
+	directRec := httptest.NewRecorder()
+	handler.ServeHTTP(directRec, directReq)
+
+	if directRec.Code != http.StatusUnauthorized {
+		t.Errorf("direct /api call: got status %d, want %d", directRec.Code, http.StatusUnauthorized)
+	}
+	if got := directRec.Header().Get("X-Rejected-By"); got != "auth" {
+		t.Errorf("direct /api call: got X-Rejected-By=%q, want auth", got)
+	}
+
+	// Page request: the preload entry for the same route must carry the
+	// same rejection status inside window.httpPreload, even though the page
+	// shell itself is still served.
+	pageReq := httptest.NewRequest(http.MethodGet, "/secret", nil)
+	pageRec := httptest.NewRecorder()
+	handler.ServeHTTP(pageRec, pageReq)
+
+	body := pageRec.Body.String()
+	if !strings.Contains(body, `"statusCode":401`) {
+		t.Errorf("page response body %q does not embed the rejected preload status", body)
+	}
+}
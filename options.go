@@ -0,0 +1,88 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures a HttpPreloaderContext at construction time. See
+// NewHttpPreloaderContext.
+type Option[T any] func(*HttpPreloaderContext[T])
+
+// WithEarlyHintsDisabled turns off the 103 Early Hints response that would
+// otherwise be sent for preloadable assets found in index.html.
+func WithEarlyHintsDisabled[T any]() Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.earlyHintsDisabled = true
+	}
+}
+
+// WithEarlyHintsFunc overrides how Link header values are derived from the
+// React index.html text, e.g. to add CDN-hosted assets that aren't present
+// in index.html itself.
+func WithEarlyHintsFunc[T any](fn func(reactIndexText string) []string) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.earlyHintsFunc = fn
+	}
+}
+
+// WithReloadInterval throttles how often index.html is re-stat'd (fsSource)
+// or re-validated against the dev server (proxySource). The default, zero,
+// checks on every request.
+func WithReloadInterval[T any](interval time.Duration) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.reloadInterval = interval
+	}
+}
+
+// WithDevServerURL overrides the dev server the preloader falls back to
+// (both for asset proxying and for fetching index.html) instead of the
+// default http://localhost:3000.
+func WithDevServerURL[T any](url string) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.devServerURL = url
+	}
+}
+
+// WithDevServerTransport overrides the http.Transport used to reach the dev
+// server, e.g. to tune dial timeouts or keep-alives.
+func WithDevServerTransport[T any](transport *http.Transport) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.devServerTransport = transport
+	}
+}
+
+// WithDevServerRetry configures how many attempts, the first one included, a
+// dev server request gets before giving up, with exponential backoff starting
+// at backoff between them.
+func WithDevServerRetry[T any](maxAttempts int, backoff time.Duration) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.devServerMaxAttempts = maxAttempts
+		ctx.devServerBackoff = backoff
+	}
+}
+
+// WithDevServerErrorHandler overrides the HTML shell rendered once every
+// retry to the dev server is exhausted.
+func WithDevServerErrorHandler[T any](h func(http.ResponseWriter, *http.Request, error)) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.devServerErrorHandler = h
+	}
+}
+
+// WithMaxBodyBytes caps how much of a handler's response body is captured
+// into the preload payload; writes past the cap are discarded silently and
+// the InterceptWriter is marked Truncated. The default, zero, is unlimited.
+func WithMaxBodyBytes[T any](n int) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.maxBodyBytes = n
+	}
+}
+
+// WithNonceFunc sets the function used to generate a per-request CSP nonce
+// for the injected window.httpPreload <script> tag. See NonceFunc.
+func WithNonceFunc[T any](fn NonceFunc) Option[T] {
+	return func(ctx *HttpPreloaderContext[T]) {
+		ctx.nonceFunc = fn
+	}
+}
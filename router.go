@@ -0,0 +1,78 @@
+package HttpPreloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// paramSegment matches a single ":name" path segment.
+var paramSegment = regexp.MustCompile(`^:([A-Za-z_][A-Za-z0-9_]*)$`)
+
+type contextKey string
+
+const pathParamsKey contextKey = "pathParams"
+
+// PathParams returns the named path parameters captured for r by the
+// matching route, populated for both the preload goroutines and the direct
+// /api call. Returns an empty map if nothing was captured.
+func PathParams(r *http.Request) map[string]string {
+	if v, ok := r.Context().Value(pathParamsKey).(map[string]string); ok {
+		return v
+	}
+	return map[string]string{}
+}
+
+func withPathParams(r *http.Request, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey, params))
+}
+
+// compilePattern turns a ":param"/"*wildcard" shorthand pattern into a regex
+// with named capture groups. A literal segment is matched verbatim.
+func compilePattern(pattern string) *regexp.Regexp {
+	segments := strings.Split(pattern, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i, seg := range segments {
+		if i > 0 {
+			b.WriteString("/")
+		}
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			name := strings.TrimPrefix(seg, "*")
+			if name == "" {
+				name = "wildcard"
+			}
+			b.WriteString(fmt.Sprintf("(?P<%s>.*)", name))
+		case paramSegment.MatchString(seg):
+			name := paramSegment.FindStringSubmatch(seg)[1]
+			b.WriteString(fmt.Sprintf("(?P<%s>[^/]+)", name))
+		default:
+			b.WriteString(regexp.QuoteMeta(seg))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// matchRoute walks the ordered routes registered for method and returns the
+// first one whose regex matches path, along with any captured named params.
+func (ctx *HttpPreloaderContext[T]) matchRoute(method, path string) (*PreloadRouteMap, map[string]string, bool) {
+	for _, route := range ctx.routes[method] {
+		m := route.regex.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		params := make(map[string]string)
+		for i, name := range route.regex.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			params[name] = m[i]
+		}
+		return route, params, true
+	}
+	return nil, nil, false
+}
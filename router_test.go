@@ -0,0 +1,122 @@
+package HttpPreloader
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func newTestCtx() *HttpPreloaderContext[struct{}] {
+	return NewHttpPreloaderContext(struct{}{}, false)
+}
+
+func TestCompilePatternParamSegment(t *testing.T) {
+	re := compilePattern("/users/:id")
+	m := re.FindStringSubmatch("/users/42")
+	if m == nil {
+		t.Fatalf("expected /users/42 to match /users/:id")
+	}
+	names := re.SubexpNames()
+	params := make(map[string]string)
+	for i, name := range names {
+		if i == 0 || name == "" {
+			continue
+		}
+		params[name] = m[i]
+	}
+	if params["id"] != "42" {
+		t.Errorf("got id=%q, want 42", params["id"])
+	}
+}
+
+func TestCompilePatternWildcard(t *testing.T) {
+	re := compilePattern("/assets/*path")
+	m := re.FindStringSubmatch("/assets/js/app.js")
+	if m == nil {
+		t.Fatalf("expected /assets/js/app.js to match /assets/*path")
+	}
+	if !re.MatchString("/assets/js/app.js") {
+		t.Fatalf("expected match")
+	}
+	_ = m
+}
+
+func TestCompilePatternLiteralDoesNotMatchExtra(t *testing.T) {
+	re := compilePattern("/health")
+	if re.MatchString("/health/deep") {
+		t.Errorf("literal pattern should not match a longer path")
+	}
+	if !re.MatchString("/health") {
+		t.Errorf("literal pattern should match itself")
+	}
+}
+
+func TestMatchRouteReturnsParams(t *testing.T) {
+	ctx := newTestCtx()
+	ctx.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	route, params, ok := ctx.matchRoute(http.MethodGet, "/users/7")
+	if !ok {
+		t.Fatalf("expected route match")
+	}
+	if route.Path != "/users/:id" {
+		t.Errorf("got route.Path=%q, want /users/:id", route.Path)
+	}
+	if params["id"] != "7" {
+		t.Errorf("got id=%q, want 7", params["id"])
+	}
+}
+
+func TestMatchRouteMethodMismatch(t *testing.T) {
+	ctx := newTestCtx()
+	ctx.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	if _, _, ok := ctx.matchRoute(http.MethodPost, "/users/7"); ok {
+		t.Errorf("expected no match for a method with no registered routes")
+	}
+}
+
+func TestMatchRoutePathMismatch(t *testing.T) {
+	ctx := newTestCtx()
+	ctx.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	if _, _, ok := ctx.matchRoute(http.MethodGet, "/orgs/7"); ok {
+		t.Errorf("expected no match for an unrelated path")
+	}
+}
+
+func TestHandleRegexMatch(t *testing.T) {
+	ctx := newTestCtx()
+	re := regexp.MustCompile(`^/files/(?P<year>\d{4})/(?P<name>[^/]+)$`)
+	ctx.HandleRegex(http.MethodGet, re, func(w http.ResponseWriter, r *http.Request) {})
+
+	route, params, ok := ctx.matchRoute(http.MethodGet, "/files/2024/report.pdf")
+	if !ok {
+		t.Fatalf("expected regex route match")
+	}
+	if route.regex != re {
+		t.Errorf("expected the registered regex to be reused")
+	}
+	if params["year"] != "2024" || params["name"] != "report.pdf" {
+		t.Errorf("got params=%v, want year=2024 name=report.pdf", params)
+	}
+}
+
+func TestPathParamsDefaultsToEmptyMap(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	params := PathParams(r)
+	if params == nil || len(params) != 0 {
+		t.Errorf("expected empty, non-nil map for a request with no params, got %v", params)
+	}
+}
+
+func TestWithPathParamsRoundTrips(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	want := map[string]string{"id": "42"}
+	r = withPathParams(r, want)
+
+	got := PathParams(r)
+	if got["id"] != "42" {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}